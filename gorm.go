@@ -21,6 +21,13 @@ type Config struct {
 	// NamingStrategy tables, columns naming strategy
 	NamingStrategy schema.Namer
 	// Logger
+	//
+	// Config.Logger keeps the legacy string-based logger.Interface contract
+	// so it stays a drop-in superset of every Trace/Info/Warn/Error call
+	// site in the callbacks. To get structured, leveled logging (Fields,
+	// sampling, trace ids, plan-change detection), build a
+	// logger.StructuredInterface and adapt it back with logger.AsLegacy
+	// before assigning it here.
 	Logger logger.Interface
 	// NowFunc the function to be used when creating a new timestamp
 	NowFunc func() time.Time
@@ -28,6 +35,8 @@ type Config struct {
 	DryRun bool
 	// PrepareStmt executes the given query in cached statement
 	PrepareStmt bool
+	// PreparedStmtConfig bounds the prepared statement cache when PrepareStmt is enabled
+	PreparedStmtConfig PreparedStmtDBConfig
 	// DisableAutomaticPing
 	DisableAutomaticPing bool
 
@@ -100,10 +109,7 @@ func Open(dialector Dialector, config *Config) (db *DB, err error) {
 	}
 
 	if config.PrepareStmt {
-		db.ConnPool = &PreparedStmtDB{
-			ConnPool: db.ConnPool,
-			Stmts:    map[string]*sql.Stmt{},
-		}
+		db.ConnPool = NewPreparedStmtDB(db.ConnPool, config.PreparedStmtConfig)
 	}
 
 	db.Statement = &Statement{
@@ -144,10 +150,7 @@ func (db *DB) Session(config *Session) *DB {
 	}
 
 	if config.PrepareStmt {
-		tx.Statement.ConnPool = &PreparedStmtDB{
-			ConnPool: db.Config.ConnPool,
-			Stmts:    map[string]*sql.Stmt{},
-		}
+		tx.Statement.ConnPool = NewPreparedStmtDB(db.Config.ConnPool, db.Config.PreparedStmtConfig)
 	}
 
 	if config.WithConditions {
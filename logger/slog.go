@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLogger adapts a *slog.Logger to StructuredInterface.
+type slogLogger struct {
+	logger *slog.Logger
+	level  LogLevel
+}
+
+// NewSlog wraps l as a StructuredInterface, emitting each query's Fields as
+// slog attributes.
+func NewSlog(l *slog.Logger) StructuredInterface {
+	return &slogLogger{logger: l, level: Warn}
+}
+
+func (s *slogLogger) LogMode(level LogLevel) StructuredInterface {
+	return &slogLogger{logger: s.logger, level: level}
+}
+
+func (s *slogLogger) Log(ctx context.Context, level LogLevel, fields Fields) {
+	if level > s.level {
+		return
+	}
+
+	attrs := make([]any, 0, len(fields)*2)
+	for k, v := range fields {
+		attrs = append(attrs, k, v)
+	}
+
+	switch level {
+	case Error:
+		s.logger.ErrorContext(ctx, "gorm query", attrs...)
+	case Warn:
+		s.logger.WarnContext(ctx, "gorm query", attrs...)
+	default:
+		s.logger.InfoContext(ctx, "gorm query", attrs...)
+	}
+}
@@ -0,0 +1,227 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Fields are the structured key/value pairs emitted for one logged query.
+// AsLegacy's Trace sets "sql", "rows", "duration" and "error"; it does not
+// set "table" or "op" (the GORM operation: query, create, update, delete,
+// row, raw), so those are present only when the caller of Log supplies them.
+type Fields map[string]interface{}
+
+// StructuredInterface is a leveled, structured logging contract: instead of
+// a preformatted string, Log receives the raw Fields so adapters can emit
+// them as key/value pairs rather than parsing a message back apart.
+//
+// StructuredInterface is deliberately kept separate from the legacy
+// Interface rather than folded into it: Interface.LogMode returns Interface,
+// so a single type can't implement both with their natural, differently
+// typed LogMode return values. Use AsLegacy to bridge a StructuredInterface
+// back to Interface (e.g. to assign one to Config.Logger).
+type StructuredInterface interface {
+	LogMode(LogLevel) StructuredInterface
+	Log(ctx context.Context, level LogLevel, fields Fields)
+}
+
+// TraceIDFunc extracts a trace/request id from ctx to attach to Fields, e.g.
+// pulling it out of `Statement.Context`.
+type TraceIDFunc func(ctx context.Context) (traceID string, ok bool)
+
+// fromLegacy adapts the old string-based Interface to StructuredInterface so
+// existing `Config.Logger` values keep working unchanged.
+type fromLegacy struct {
+	Interface
+}
+
+// NewFromInterface wraps a string-based logger.Interface as a
+// StructuredInterface, for backward compatibility with the pre-existing
+// logging contract.
+func NewFromInterface(legacy Interface) StructuredInterface {
+	return fromLegacy{Interface: legacy}
+}
+
+func (l fromLegacy) LogMode(level LogLevel) StructuredInterface {
+	return fromLegacy{Interface: l.Interface.LogMode(level)}
+}
+
+func (l fromLegacy) Log(ctx context.Context, level LogLevel, fields Fields) {
+	sql, _ := fields["sql"].(string)
+	err, _ := fields["error"].(error)
+
+	switch level {
+	case Error:
+		l.Interface.Error(ctx, sql)
+	case Warn:
+		l.Interface.Warn(ctx, sql)
+	default:
+		l.Interface.Info(ctx, sql)
+	}
+
+	if err != nil {
+		l.Interface.Error(ctx, "%v", err)
+	}
+}
+
+// legacyShim adapts a StructuredInterface back to the legacy Interface, so
+// sampler/trace-id/plan-change/slog/zap/zerolog loggers (or any other
+// StructuredInterface) can be assigned to Config.Logger directly and
+// actually receive the Trace/Info/Warn/Error calls GORM's callbacks make,
+// funneled into Log as Fields.
+type legacyShim struct {
+	StructuredInterface
+}
+
+// AsLegacy adapts s to the legacy, string-based Interface, so it can be
+// assigned to Config.Logger. Trace/Info/Warn/Error are translated into s.Log
+// calls carrying Fields ("sql", "rows", "duration", "error" for Trace).
+func AsLegacy(s StructuredInterface) Interface {
+	return legacyShim{StructuredInterface: s}
+}
+
+func (l legacyShim) LogMode(level LogLevel) Interface {
+	return AsLegacy(l.StructuredInterface.LogMode(level))
+}
+
+func (l legacyShim) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.Log(ctx, Info, Fields{"sql": fmt.Sprintf(msg, args...)})
+}
+
+func (l legacyShim) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.Log(ctx, Warn, Fields{"sql": fmt.Sprintf(msg, args...)})
+}
+
+func (l legacyShim) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.Log(ctx, Error, Fields{"sql": fmt.Sprintf(msg, args...)})
+}
+
+// Trace implements Interface.Trace, the call every GORM callback makes after
+// running a query, by funneling its arguments into Log as Fields.
+func (l legacyShim) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	level := Info
+	if err != nil {
+		level = Error
+	}
+
+	sql, rows := fc()
+	l.Log(ctx, level, Fields{
+		"sql":      sql,
+		"rows":     rows,
+		"duration": time.Since(begin),
+		"error":    err,
+	})
+}
+
+// Sampler wraps a StructuredInterface, logging only 1 in every `Rate` fast
+// queries while always logging queries at or above `SlowThreshold` and
+// anything logged at Warn/Error.
+type Sampler struct {
+	StructuredInterface
+	Rate          int
+	SlowThreshold time.Duration
+
+	counter uint64
+}
+
+// LogMode returns a new Sampler wrapping the inner logger's own LogMode
+// result, so the sampling layer survives a LogMode call (e.g. db.Debug()).
+func (s *Sampler) LogMode(level LogLevel) StructuredInterface {
+	return &Sampler{
+		StructuredInterface: s.StructuredInterface.LogMode(level),
+		Rate:                s.Rate,
+		SlowThreshold:       s.SlowThreshold,
+	}
+}
+
+func (s *Sampler) Log(ctx context.Context, level LogLevel, fields Fields) {
+	if level <= Warn {
+		// Error and Warn are more severe than Info and always bypass sampling.
+		s.StructuredInterface.Log(ctx, level, fields)
+		return
+	}
+
+	if d, ok := fields["duration"].(time.Duration); ok && s.SlowThreshold > 0 && d >= s.SlowThreshold {
+		s.StructuredInterface.Log(ctx, level, fields)
+		return
+	}
+
+	rate := s.Rate
+	if rate <= 1 {
+		s.StructuredInterface.Log(ctx, level, fields)
+		return
+	}
+
+	if atomic.AddUint64(&s.counter, 1)%uint64(rate) == 0 {
+		s.StructuredInterface.Log(ctx, level, fields)
+	}
+}
+
+// WithTraceID returns a StructuredInterface that attaches a "trace_id" field
+// pulled from ctx via extract, when present.
+func WithTraceID(next StructuredInterface, extract TraceIDFunc) StructuredInterface {
+	return &traceIDLogger{StructuredInterface: next, extract: extract}
+}
+
+type traceIDLogger struct {
+	StructuredInterface
+	extract TraceIDFunc
+}
+
+// LogMode returns a new traceIDLogger wrapping the inner logger's own
+// LogMode result, so the trace-id layer survives a LogMode call.
+func (l *traceIDLogger) LogMode(level LogLevel) StructuredInterface {
+	return &traceIDLogger{StructuredInterface: l.StructuredInterface.LogMode(level), extract: l.extract}
+}
+
+func (l *traceIDLogger) Log(ctx context.Context, level LogLevel, fields Fields) {
+	if id, ok := l.extract(ctx); ok {
+		fields = cloneFields(fields)
+		fields["trace_id"] = id
+	}
+	l.StructuredInterface.Log(ctx, level, fields)
+}
+
+func cloneFields(fields Fields) Fields {
+	clone := make(Fields, len(fields)+1)
+	for k, v := range fields {
+		clone[k] = v
+	}
+	return clone
+}
+
+// PlanChangeDetector logs a Warn-level event the first time a table+op pair's
+// query text changes from what was last seen, which often signals a changed
+// execution plan (e.g. an index stopped being used).
+type PlanChangeDetector struct {
+	StructuredInterface
+
+	seen sync.Map // map[string]string, table+op -> last seen sql
+}
+
+// LogMode returns a new PlanChangeDetector wrapping the inner logger's own
+// LogMode result, so the plan-change layer survives a LogMode call.
+func (d *PlanChangeDetector) LogMode(level LogLevel) StructuredInterface {
+	return &PlanChangeDetector{StructuredInterface: d.StructuredInterface.LogMode(level)}
+}
+
+func (d *PlanChangeDetector) Log(ctx context.Context, level LogLevel, fields Fields) {
+	table, _ := fields["table"].(string)
+	op, _ := fields["op"].(string)
+	sql, _ := fields["sql"].(string)
+
+	if table != "" && sql != "" {
+		key := table + ":" + op
+		if last, ok := d.seen.Load(key); ok && last.(string) != sql {
+			changed := cloneFields(fields)
+			changed["previous_sql"] = last
+			d.StructuredInterface.Log(ctx, Warn, changed)
+		}
+		d.seen.Store(key, sql)
+	}
+
+	d.StructuredInterface.Log(ctx, level, fields)
+}
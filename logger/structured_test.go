@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeStructuredLogger is a minimal StructuredInterface that records calls
+// and the level it was put into via LogMode, for asserting wrapper behavior.
+type fakeStructuredLogger struct {
+	level LogLevel
+	logs  []Fields
+}
+
+func (f *fakeStructuredLogger) LogMode(level LogLevel) StructuredInterface {
+	return &fakeStructuredLogger{level: level, logs: f.logs}
+}
+
+func (f *fakeStructuredLogger) Log(ctx context.Context, level LogLevel, fields Fields) {
+	f.logs = append(f.logs, fields)
+}
+
+func TestSamplerLogModeReturnsSamplerWrappingReLogModedInner(t *testing.T) {
+	inner := &fakeStructuredLogger{}
+	s := &Sampler{StructuredInterface: inner, Rate: 10, SlowThreshold: 0}
+
+	wrapped := s.LogMode(Info)
+
+	sampler, ok := wrapped.(*Sampler)
+	if !ok {
+		t.Fatalf("expected LogMode to return a *Sampler, got %T", wrapped)
+	}
+	if sampler.Rate != s.Rate || sampler.SlowThreshold != s.SlowThreshold {
+		t.Fatalf("expected sampling config to survive LogMode, got %+v", sampler)
+	}
+	inner, ok = sampler.StructuredInterface.(*fakeStructuredLogger)
+	if !ok || inner.level != Info {
+		t.Fatalf("expected inner logger to be re-LogMode'd to Info")
+	}
+}
+
+func TestTraceIDLoggerLogModePreservesExtractFunc(t *testing.T) {
+	extract := func(ctx context.Context) (string, bool) { return "abc", true }
+	l := &traceIDLogger{StructuredInterface: &fakeStructuredLogger{}, extract: extract}
+
+	wrapped := l.LogMode(Warn)
+
+	traced, ok := wrapped.(*traceIDLogger)
+	if !ok {
+		t.Fatalf("expected LogMode to return a *traceIDLogger, got %T", wrapped)
+	}
+
+	traced.Log(context.Background(), Warn, Fields{})
+	inner := traced.StructuredInterface.(*fakeStructuredLogger)
+	if len(inner.logs) != 1 || inner.logs[0]["trace_id"] != "abc" {
+		t.Fatalf("expected the wrapped logger to keep attaching trace ids, got %+v", inner.logs)
+	}
+}
+
+func TestPlanChangeDetectorLogModeReturnsPlanChangeDetector(t *testing.T) {
+	d := &PlanChangeDetector{StructuredInterface: &fakeStructuredLogger{}}
+
+	wrapped := d.LogMode(Error)
+
+	detector, ok := wrapped.(*PlanChangeDetector)
+	if !ok {
+		t.Fatalf("expected LogMode to return a *PlanChangeDetector, got %T", wrapped)
+	}
+	inner := detector.StructuredInterface.(*fakeStructuredLogger)
+	if inner.level != Error {
+		t.Fatalf("expected inner logger to be re-LogMode'd to Error, got %v", inner.level)
+	}
+}
+
+func TestPlanChangeDetectorWarnsOnChangedSQL(t *testing.T) {
+	inner := &fakeStructuredLogger{}
+	d := &PlanChangeDetector{StructuredInterface: inner}
+
+	d.Log(context.Background(), Info, Fields{"table": "users", "op": "query", "sql": "SELECT 1"})
+	d.Log(context.Background(), Info, Fields{"table": "users", "op": "query", "sql": "SELECT 2"})
+
+	if len(inner.logs) != 3 {
+		t.Fatalf("expected 2 passthrough logs plus 1 warn on the changed query, got %d", len(inner.logs))
+	}
+	if inner.logs[1]["previous_sql"] != "SELECT 1" {
+		t.Fatalf("expected the warn log to record the previous sql, got %+v", inner.logs[1])
+	}
+}
+
+func TestAsLegacyTraceEmitsFieldsWithErrorLevel(t *testing.T) {
+	inner := &fakeStructuredLogger{}
+	legacy := AsLegacy(inner)
+
+	boom := errors.New("boom")
+	legacy.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return "SELECT 1", 1
+	}, boom)
+
+	if len(inner.logs) != 1 {
+		t.Fatalf("expected Trace to emit exactly one Log call, got %d", len(inner.logs))
+	}
+	got := inner.logs[0]
+	if got["sql"] != "SELECT 1" || got["rows"] != int64(1) || got["error"] != boom {
+		t.Fatalf("expected Trace to populate sql/rows/error fields, got %+v", got)
+	}
+}
+
+func TestAsLegacyLogModeRewrapsAsLegacy(t *testing.T) {
+	inner := &fakeStructuredLogger{}
+	legacy := AsLegacy(inner)
+
+	wrapped := legacy.LogMode(Warn)
+
+	wrapped.Info(context.Background(), "hello %s", "world")
+	shim, ok := wrapped.(legacyShim)
+	if !ok {
+		t.Fatalf("expected LogMode to return a legacyShim, got %T", wrapped)
+	}
+	structured := shim.StructuredInterface.(*fakeStructuredLogger)
+	if structured.level != Warn {
+		t.Fatalf("expected the inner logger to be re-LogMode'd to Warn, got %v", structured.level)
+	}
+	if len(structured.logs) != 1 || structured.logs[0]["sql"] != "hello world" {
+		t.Fatalf("expected Info to funnel into Log with a formatted sql field, got %+v", structured.logs)
+	}
+}
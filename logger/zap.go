@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// zapLogger adapts a *zap.Logger to StructuredInterface.
+type zapLogger struct {
+	logger *zap.Logger
+	level  LogLevel
+}
+
+// NewZap wraps l as a StructuredInterface, emitting each query's Fields as
+// zap.Any fields.
+func NewZap(l *zap.Logger) StructuredInterface {
+	return &zapLogger{logger: l, level: Warn}
+}
+
+func (z *zapLogger) LogMode(level LogLevel) StructuredInterface {
+	return &zapLogger{logger: z.logger, level: level}
+}
+
+func (z *zapLogger) Log(_ context.Context, level LogLevel, fields Fields) {
+	if level > z.level {
+		return
+	}
+
+	zapFields := make([]zap.Field, 0, len(fields))
+	for k, v := range fields {
+		zapFields = append(zapFields, zap.Any(k, v))
+	}
+
+	ce := z.logger.Check(zapLevel(level), "gorm query")
+	if ce != nil {
+		ce.Write(zapFields...)
+	}
+}
+
+func zapLevel(level LogLevel) zapcore.Level {
+	switch level {
+	case Error:
+		return zapcore.ErrorLevel
+	case Warn:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
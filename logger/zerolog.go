@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// zerologLogger adapts a zerolog.Logger to StructuredInterface.
+type zerologLogger struct {
+	logger zerolog.Logger
+	level  LogLevel
+}
+
+// NewZerolog wraps l as a StructuredInterface, emitting each query's Fields
+// as zerolog's key/value pairs.
+func NewZerolog(l zerolog.Logger) StructuredInterface {
+	return &zerologLogger{logger: l, level: Warn}
+}
+
+func (z *zerologLogger) LogMode(level LogLevel) StructuredInterface {
+	return &zerologLogger{logger: z.logger, level: level}
+}
+
+func (z *zerologLogger) Log(ctx context.Context, level LogLevel, fields Fields) {
+	if level > z.level {
+		return
+	}
+
+	var event *zerolog.Event
+	switch level {
+	case Error:
+		event = z.logger.Error()
+	case Warn:
+		event = z.logger.Warn()
+	default:
+		event = z.logger.Info()
+	}
+
+	for k, v := range fields {
+		event = event.Interface(k, v)
+	}
+
+	event.Ctx(ctx).Msg("gorm query")
+}
@@ -0,0 +1,31 @@
+package migrator
+
+import (
+	"hash/fnv"
+
+	"gorm.io/gorm"
+)
+
+// AdvisoryLockerFunc acquires a named, dialect-specific advisory lock against
+// db, returning the function that releases it.
+type AdvisoryLockerFunc func(db *gorm.DB, name string) (unlock func() error, err error)
+
+// advisoryLockers maps a Dialector's Name() to the locker that knows how to
+// take an advisory lock on it. This package registers "postgres", "mysql" and
+// "sqlite" in init() funcs; other dialects can be added via
+// RegisterAdvisoryLocker. Dialects without an entry simply run unlocked.
+var advisoryLockers = map[string]AdvisoryLockerFunc{}
+
+// RegisterAdvisoryLocker registers locker as the advisory lock implementation
+// for the dialector named dialectorName (e.g. "postgres", "mysql", "sqlite").
+func RegisterAdvisoryLocker(dialectorName string, locker AdvisoryLockerFunc) {
+	advisoryLockers[dialectorName] = locker
+}
+
+// lockKey hashes an arbitrary lock name down to an int64, for dialects (like
+// postgres) whose advisory lock functions take a numeric key.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,28 @@
+package migrator
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	RegisterAdvisoryLocker("mysql", mysqlAdvisoryLock)
+}
+
+// mysqlAdvisoryLock takes a named lock via GET_LOCK, released with
+// RELEASE_LOCK. It waits indefinitely (timeout -1) for concurrent deploys to
+// take their turn rather than failing fast.
+func mysqlAdvisoryLock(db *gorm.DB, name string) (func() error, error) {
+	var acquired int
+	if err := db.Raw("SELECT GET_LOCK(?, -1)", name).Scan(&acquired).Error; err != nil {
+		return nil, err
+	}
+	if acquired != 1 {
+		return nil, fmt.Errorf("migrator: failed to acquire MySQL lock %q", name)
+	}
+
+	return func() error {
+		return db.Exec("SELECT RELEASE_LOCK(?)", name).Error
+	}, nil
+}
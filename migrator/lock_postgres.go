@@ -0,0 +1,21 @@
+package migrator
+
+import "gorm.io/gorm"
+
+func init() {
+	RegisterAdvisoryLocker("postgres", postgresAdvisoryLock)
+}
+
+// postgresAdvisoryLock takes a session-level advisory lock via
+// pg_advisory_lock, released with pg_advisory_unlock.
+func postgresAdvisoryLock(db *gorm.DB, name string) (func() error, error) {
+	key := lockKey(name)
+
+	if err := db.Exec("SELECT pg_advisory_lock(?)", key).Error; err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return db.Exec("SELECT pg_advisory_unlock(?)", key).Error
+	}, nil
+}
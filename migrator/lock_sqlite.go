@@ -0,0 +1,38 @@
+package migrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	RegisterAdvisoryLocker("sqlite", sqliteAdvisoryLock)
+}
+
+// sqliteAdvisoryLock takes an exclusive file lock, since SQLite has no
+// session-level advisory lock primitive. The lock file is created with
+// O_EXCL so only one process can hold it at a time; on contention it retries
+// every 50ms for up to 30 seconds rather than failing on first contention.
+func sqliteAdvisoryLock(_ *gorm.DB, name string) (func() error, error) {
+	path := filepath.Join(os.TempDir(), "gorm-migrator-"+name+".lock")
+	deadline := time.Now().Add(30 * time.Second)
+
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() error { return os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("migrator: timed out waiting for sqlite migration lock %q", name)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,61 @@
+package migrator
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeDriver is a minimal database/sql driver good enough to hand out a real
+// *sql.Conn, for testing pinConnection without a real database.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (*fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (*fakeConn) Close() error                              { return nil }
+func (*fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+var registerFakeDriverOnce sync.Once
+
+func newFakeConnPool(t *testing.T) *sql.DB {
+	t.Helper()
+	registerFakeDriverOnce.Do(func() { sql.Register("migrator-fake", fakeDriver{}) })
+	db, err := sql.Open("migrator-fake", "")
+	if err != nil {
+		t.Fatalf("failed to open fake db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestPinConnectionReturnsADedicatedConnection(t *testing.T) {
+	db, err := gorm.Open(nil, &gorm.Config{ConnPool: newFakeConnPool(t)})
+	if err != nil {
+		t.Fatalf("unexpected error opening db: %v", err)
+	}
+
+	pinned, closePinned, err := pinConnection(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer closePinned()
+
+	conn, ok := pinned.Statement.ConnPool.(*sql.Conn)
+	if !ok {
+		t.Fatalf("expected pinned Statement.ConnPool to be a *sql.Conn, got %T", pinned.Statement.ConnPool)
+	}
+	if conn == nil {
+		t.Fatalf("expected a non-nil *sql.Conn")
+	}
+
+	// The original db's pool must be left untouched.
+	if _, ok := db.Statement.ConnPool.(*sql.Conn); ok {
+		t.Fatalf("expected the original db's ConnPool to remain the shared pool, not the pinned connection")
+	}
+}
@@ -0,0 +1,410 @@
+package migrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one versioned migration step. ID must be unique and sortable
+// (e.g. a timestamp or zero-padded sequence prefix) since migrations run in
+// ID order.
+type Migration struct {
+	ID   string
+	Up   func(*gorm.DB) error
+	Down func(*gorm.DB) error
+	// Checksum detects drift between the migration recorded as applied and
+	// the one currently in the source. Sources that can read their own
+	// content (e.g. FSSource) fill this in; Go-func migrations registered
+	// directly via Migrations should set it explicitly if they want drift
+	// detection beyond the best-effort fallback in checksumFor.
+	Checksum string
+}
+
+// Source supplies an ordered set of migrations, e.g. a Go slice or a
+// directory of `.sql` files loaded from an fs.FS.
+type Source interface {
+	Migrations() ([]Migration, error)
+}
+
+// Migrations is a Source backed by a plain, already-ordered slice of Go migrations.
+type Migrations []Migration
+
+func (m Migrations) Migrations() ([]Migration, error) { return m, nil }
+
+// FSSource loads paired `<id>.up.sql` / `<id>.down.sql` files from an fs.FS
+// (typically an embed.FS), ordered by filename.
+type FSSource struct {
+	FS fs.FS
+}
+
+func (s FSSource) Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(s.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrator: failed to read migration source: %w", err)
+	}
+
+	ids := map[string]struct{}{}
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasSuffix(name, ".up.sql") {
+			ids[strings.TrimSuffix(name, ".up.sql")] = struct{}{}
+		}
+	}
+
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	migrations := make([]Migration, 0, len(sorted))
+	for _, id := range sorted {
+		id := id
+
+		upSQL, err := fs.ReadFile(s.FS, id+".up.sql")
+		if err != nil {
+			return nil, fmt.Errorf("migrator: failed to read %s.up.sql: %w", id, err)
+		}
+
+		migrations = append(migrations, Migration{
+			ID: id,
+			Up: func(tx *gorm.DB) error {
+				return tx.Exec(string(upSQL)).Error
+			},
+			Down: func(tx *gorm.DB) error {
+				sqlBytes, err := fs.ReadFile(s.FS, id+".down.sql")
+				if err != nil {
+					return err
+				}
+				return tx.Exec(string(sqlBytes)).Error
+			},
+			Checksum: contentChecksum(upSQL),
+		})
+	}
+
+	return migrations, nil
+}
+
+// schemaMigration is the row shape of the tracking table.
+type schemaMigration struct {
+	ID        string `gorm:"primarykey"`
+	AppliedAt time.Time
+	Checksum  string
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// Status reports which migrations from a Source are already applied.
+type Status struct {
+	Applied []string
+	Pending []string
+	// Drifted lists applied migrations whose current source checksum no
+	// longer matches the one recorded when they ran.
+	Drifted []string
+}
+
+// VersionedMigrator runs ordered migrations from a Source against db,
+// tracking progress in a `schema_migrations` table.
+type VersionedMigrator struct {
+	DB *gorm.DB
+}
+
+// New returns a VersionedMigrator bound to db.
+func New(db *gorm.DB) *VersionedMigrator {
+	return &VersionedMigrator{DB: db}
+}
+
+func (m *VersionedMigrator) withLock(fn func(*gorm.DB) error) error {
+	db := m.DB
+	unlock := func() error { return nil }
+
+	if db.Dialector != nil {
+		if locker, ok := advisoryLockers[db.Dialector.Name()]; ok {
+			pinned, closePinned, err := pinConnection(db)
+			if err != nil {
+				return fmt.Errorf("migrator: failed to reserve a connection for migration locking: %w", err)
+			}
+			defer closePinned()
+			db = pinned
+
+			unlock, err = locker(db, "gorm:schema_migrations")
+			if err != nil {
+				return fmt.Errorf("migrator: failed to acquire migration lock: %w", err)
+			}
+		}
+	}
+	defer unlock()
+
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("migrator: failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(db)
+}
+
+// pinConnection reserves a single *sql.Conn from db's pool and returns a
+// *gorm.DB bound to it. Session/named advisory locks (pg_advisory_lock,
+// GET_LOCK) are scoped to the connection that took them, so the lock,
+// AutoMigrate, every migration, and the unlock must all run on that same
+// connection rather than whichever one the pool happens to hand out next -
+// otherwise two concurrent migrators can each "acquire" the lock on a
+// different connection and race.
+func pinConnection(db *gorm.DB) (*gorm.DB, func() error, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := sqlDB.Conn(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pinned := db.Session(&gorm.Session{Context: db.Statement.Context})
+	pinned.Statement.ConnPool = conn
+
+	return pinned, conn.Close, nil
+}
+
+// contentChecksum hashes the actual bytes of a migration (e.g. its .sql
+// source) so a changed migration body is detected as drift.
+func contentChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// checksumFor returns m's checksum, preferring one the Source already
+// computed from real content. For a Go-func migration with no explicit
+// Checksum, this falls back to hashing the Up func's code pointer: it won't
+// catch an edited function body, but it does catch the function being
+// swapped for a different one, which an ID-only hash can never detect.
+func checksumFor(m Migration) string {
+	if m.Checksum != "" {
+		return m.Checksum
+	}
+	if m.Up != nil {
+		ptr := reflect.ValueOf(m.Up).Pointer()
+		return contentChecksum([]byte(fmt.Sprintf("%s:%x", m.ID, ptr)))
+	}
+	return contentChecksum([]byte(m.ID))
+}
+
+// hasDrifted reports whether migration's current source checksum no longer
+// matches the one recorded in row when it was applied.
+func hasDrifted(migration Migration, row schemaMigration) bool {
+	return row.Checksum != checksumFor(migration)
+}
+
+// Apply runs every pending migration from source, in order, each inside its
+// own transaction where the dialect supports transactional DDL.
+func (m *VersionedMigrator) Apply(source Source) error {
+	return m.withLock(func(db *gorm.DB) error {
+		migrations, err := source.Migrations()
+		if err != nil {
+			return err
+		}
+
+		applied, err := m.appliedSet(db)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			row, ok := applied[migration.ID]
+			if !ok {
+				if err := m.runUp(db, migration); err != nil {
+					return fmt.Errorf("migrator: migration %q failed: %w", migration.ID, err)
+				}
+				continue
+			}
+			if hasDrifted(migration, row) {
+				return fmt.Errorf("migrator: migration %q has changed since it was applied (checksum drift: recorded %s, current %s)", migration.ID, row.Checksum, checksumFor(migration))
+			}
+		}
+
+		return nil
+	})
+}
+
+// MigrateTo applies or rolls back migrations so that exactly the migrations
+// up to and including id have been applied.
+func (m *VersionedMigrator) MigrateTo(source Source, id string) error {
+	return m.withLock(func(db *gorm.DB) error {
+		migrations, err := source.Migrations()
+		if err != nil {
+			return err
+		}
+
+		applied, err := m.appliedSet(db)
+		if err != nil {
+			return err
+		}
+
+		target := -1
+		for i, migration := range migrations {
+			if migration.ID == id {
+				target = i
+				break
+			}
+		}
+		if target == -1 {
+			return fmt.Errorf("migrator: unknown migration id %q", id)
+		}
+
+		for i, migration := range migrations {
+			if i > target {
+				break
+			}
+			if _, isApplied := applied[migration.ID]; !isApplied {
+				if err := m.runUp(db, migration); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Roll back anything after target, newest-first, to respect
+		// inter-migration dependencies (e.g. a later migration's FK on an
+		// earlier one).
+		for _, migration := range rollbackTargets(migrations, applied, target) {
+			if err := m.runDown(db, migration); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// rollbackTargets returns the applied migrations after index target, in
+// newest-first (descending index) order.
+func rollbackTargets(migrations []Migration, applied map[string]schemaMigration, target int) []Migration {
+	var targets []Migration
+	for i := len(migrations) - 1; i > target; i-- {
+		if _, isApplied := applied[migrations[i].ID]; isApplied {
+			targets = append(targets, migrations[i])
+		}
+	}
+	return targets
+}
+
+// Rollback undoes the last n applied migrations, most recent first.
+func (m *VersionedMigrator) Rollback(source Source, n int) error {
+	return m.withLock(func(db *gorm.DB) error {
+		migrations, err := source.Migrations()
+		if err != nil {
+			return err
+		}
+
+		byID := map[string]Migration{}
+		for _, migration := range migrations {
+			byID[migration.ID] = migration
+		}
+
+		var rows []schemaMigration
+		if err := db.Order("applied_at desc, id desc").Limit(n).Find(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			migration, ok := byID[row.ID]
+			if !ok {
+				return fmt.Errorf("migrator: applied migration %q not found in source", row.ID)
+			}
+			if err := m.runDown(db, migration); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Redo rolls back the last applied migration and then runs Apply, which
+// re-applies it together with any other pending migrations.
+func (m *VersionedMigrator) Redo(source Source) error {
+	if err := m.Rollback(source, 1); err != nil {
+		return err
+	}
+	return m.Apply(source)
+}
+
+// Status reports which migrations from source are applied vs pending.
+func (m *VersionedMigrator) Status(source Source) (Status, error) {
+	var status Status
+
+	err := m.withLock(func(db *gorm.DB) error {
+		migrations, err := source.Migrations()
+		if err != nil {
+			return err
+		}
+
+		applied, err := m.appliedSet(db)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			row, ok := applied[migration.ID]
+			if !ok {
+				status.Pending = append(status.Pending, migration.ID)
+				continue
+			}
+			status.Applied = append(status.Applied, migration.ID)
+			if hasDrifted(migration, row) {
+				status.Drifted = append(status.Drifted, migration.ID)
+			}
+		}
+
+		return nil
+	})
+
+	return status, err
+}
+
+func (m *VersionedMigrator) appliedSet(db *gorm.DB) (map[string]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("migrator: failed to read schema_migrations: %w", err)
+	}
+
+	applied := make(map[string]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.ID] = row
+	}
+	return applied, nil
+}
+
+func (m *VersionedMigrator) runUp(db *gorm.DB, migration Migration) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if migration.Up != nil {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&schemaMigration{
+			ID:        migration.ID,
+			AppliedAt: time.Now(),
+			Checksum:  checksumFor(migration),
+		}).Error
+	})
+}
+
+func (m *VersionedMigrator) runDown(db *gorm.DB, migration Migration) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if migration.Down != nil {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&schemaMigration{ID: migration.ID}).Error
+	})
+}
@@ -0,0 +1,84 @@
+package migrator
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestChecksumForPrefersExplicitChecksum(t *testing.T) {
+	m := Migration{ID: "001", Checksum: "abc123"}
+	if got := checksumFor(m); got != "abc123" {
+		t.Fatalf("expected explicit checksum to win, got %q", got)
+	}
+}
+
+func TestChecksumForDiffersWhenUpFuncDiffers(t *testing.T) {
+	a := Migration{ID: "001", Up: func(db *gorm.DB) error { return nil }}
+	b := Migration{ID: "001", Up: func(db *gorm.DB) error { return nil }}
+
+	if checksumFor(a) == checksumFor(b) {
+		t.Fatalf("expected distinct Up funcs to produce distinct checksums")
+	}
+}
+
+func TestRollbackTargetsAreNewestFirst(t *testing.T) {
+	migrations := []Migration{{ID: "001"}, {ID: "002"}, {ID: "003"}, {ID: "004"}}
+	applied := map[string]schemaMigration{
+		"001": {ID: "001"}, "002": {ID: "002"}, "003": {ID: "003"}, "004": {ID: "004"},
+	}
+
+	targets := rollbackTargets(migrations, applied, 0)
+
+	got := make([]string, len(targets))
+	for i, m := range targets {
+		got[i] = m.ID
+	}
+
+	want := []string{"004", "003", "002"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestRollbackTargetsSkipsUnapplied(t *testing.T) {
+	migrations := []Migration{{ID: "001"}, {ID: "002"}, {ID: "003"}}
+	applied := map[string]schemaMigration{"001": {ID: "001"}} // 002, 003 never ran
+
+	targets := rollbackTargets(migrations, applied, 0)
+	if len(targets) != 0 {
+		t.Fatalf("expected no rollback targets when later migrations were never applied, got %v", targets)
+	}
+}
+
+func TestHasDriftedDetectsChangedContent(t *testing.T) {
+	m := Migration{ID: "001", Checksum: contentChecksum([]byte("CREATE TABLE t (id int);"))}
+	recorded := schemaMigration{ID: "001", Checksum: contentChecksum([]byte("CREATE TABLE t (id int);"))}
+
+	if hasDrifted(m, recorded) {
+		t.Fatalf("expected no drift when the recorded and current checksums match")
+	}
+
+	recorded.Checksum = contentChecksum([]byte("CREATE TABLE t (id int not null);"))
+	if !hasDrifted(m, recorded) {
+		t.Fatalf("expected drift when the recorded checksum no longer matches the source")
+	}
+}
+
+func TestContentChecksumIsDeterministic(t *testing.T) {
+	a := contentChecksum([]byte("CREATE TABLE users (id int);"))
+	b := contentChecksum([]byte("CREATE TABLE users (id int);"))
+	c := contentChecksum([]byte("CREATE TABLE users (id int not null);"))
+
+	if a != b {
+		t.Fatalf("expected identical content to hash identically")
+	}
+	if a == c {
+		t.Fatalf("expected different content to hash differently")
+	}
+}
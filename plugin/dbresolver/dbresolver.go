@@ -0,0 +1,184 @@
+// Package dbresolver adds read/write splitting and multiple source/replica
+// support to GORM. Register it with `db.Use(dbresolver.Register(...))` and it
+// will route queries to the right `gorm.ConnPool` based on the operation
+// being performed.
+package dbresolver
+
+import (
+	"database/sql"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// Write marks the following chain as requiring the source (primary) pool,
+	// e.g. `db.Clauses(dbresolver.Write).Find(&users)` to read from source.
+	Write = Call{Op: "write"}
+	// Read marks the following chain as requiring a replica pool,
+	// e.g. `db.Clauses(dbresolver.Read).Create(&user)` to write from a replica config.
+	Read = Call{Op: "read"}
+)
+
+// Call is a gorm.Clause used to override the default operation-based routing.
+type Call struct {
+	Op string
+}
+
+func (Call) Name() string {
+	return "dbresolver:call"
+}
+
+func (c Call) Build(gorm.Builder) {}
+
+func (c Call) MergeClause(clause *gorm.Clause) {
+	clause.Expression = c
+}
+
+// Config configures one resolver: a set of sources, a set of replicas and the
+// policy used to pick among them.
+type Config struct {
+	Sources           []gorm.Dialector
+	Replicas          []gorm.Dialector
+	Policy            Policy
+	TraceResolverMode bool
+}
+
+// modelBinding scopes a resolver to a set of not-yet-resolved models/table
+// names. Resolving the table name requires a real `*gorm.DB` (schema
+// parsing reads its NamingStrategy and cacheStore), which isn't available
+// until Initialize runs, so Register/For stash the raw sources here instead
+// of resolving them eagerly.
+type modelBinding struct {
+	sources  []interface{}
+	resolver *resolver
+}
+
+// DBResolver is a GORM plugin implementing `gorm.Plugin`. It keeps a default
+// resolver plus one resolver per schema/table registered via `For`.
+type DBResolver struct {
+	*gorm.DB
+	resolvers sync.Map // map[string]*resolver, populated at Initialize
+	global    *resolver
+	bindings  []modelBinding
+}
+
+// Register creates a DBResolver plugin with an initial (default) config. Call
+// `.For(models...)` on the result to scope additional configs to models.
+func Register(config Config, sources ...interface{}) *DBResolver {
+	return (&DBResolver{}).Register(config, sources...)
+}
+
+// Register adds another config, optionally scoped to the given models/tables.
+func (dr *DBResolver) Register(config Config, sources ...interface{}) *DBResolver {
+	r := newResolver(dr, config)
+
+	if len(sources) == 0 {
+		dr.global = r
+	} else {
+		dr.bindings = append(dr.bindings, modelBinding{sources: sources, resolver: r})
+	}
+
+	return dr
+}
+
+// For scopes the default config (the one registered without models) to the
+// given models/tables, for the `db.Use(dbresolver.Register(cfg).For(&User{}))`
+// chain form. It does nothing when no default config has been registered.
+func (dr *DBResolver) For(sources ...interface{}) *DBResolver {
+	if dr.global == nil {
+		return dr
+	}
+	dr.bindings = append(dr.bindings, modelBinding{sources: sources, resolver: dr.global})
+	return dr
+}
+
+// Name implements gorm.Plugin.
+func (DBResolver) Name() string {
+	return "gorm:dbresolver"
+}
+
+// Initialize implements gorm.Plugin. It wires resolver lookups into the
+// callbacks so the statement's ConnPool is swapped before the query runs.
+func (dr *DBResolver) Initialize(db *gorm.DB) error {
+	dr.DB = db
+	dr.registerCallbacks(db)
+
+	for _, binding := range dr.bindings {
+		for _, source := range binding.sources {
+			dr.resolvers.Store(tableNameFor(db, source), binding.resolver)
+		}
+	}
+
+	if dr.global != nil {
+		if err := dr.global.init(); err != nil {
+			return err
+		}
+	}
+
+	var initErr error
+	dr.resolvers.Range(func(_, v interface{}) bool {
+		initErr = v.(*resolver).init()
+		return initErr == nil
+	})
+
+	return initErr
+}
+
+func (dr *DBResolver) registerCallbacks(db *gorm.DB) {
+	dr.Callback().Create().Before("*").Register("dbresolver:before_create", dr.switchConnPool("write"))
+	dr.Callback().Query().Before("*").Register("dbresolver:before_query", dr.switchConnPool("read"))
+	dr.Callback().Update().Before("*").Register("dbresolver:before_update", dr.switchConnPool("write"))
+	dr.Callback().Delete().Before("*").Register("dbresolver:before_delete", dr.switchConnPool("write"))
+	dr.Callback().Row().Before("*").Register("dbresolver:before_row", dr.switchConnPool("read"))
+	dr.Callback().Raw().Before("*").Register("dbresolver:before_raw", dr.switchConnPool("read"))
+}
+
+// switchConnPool returns a callback that resolves the right ConnPool for op
+// ("read" or "write"), honoring an explicit dbresolver.Read/Write clause and
+// keeping a transaction pinned to the pool it started on.
+func (dr *DBResolver) switchConnPool(op string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		if tx.Error != nil {
+			return
+		}
+
+		if _, inTransaction := tx.Statement.ConnPool.(*sql.Tx); inTransaction {
+			// a transaction sticks to whichever pool it was opened on
+			return
+		}
+
+		effectiveOp := op
+		if call, ok := tx.Statement.Clauses["dbresolver:call"].Expression.(Call); ok {
+			effectiveOp = call.Op
+		}
+
+		r := dr.resolverFor(tx.Statement.Table)
+		pool, err := r.resolve(tx.Statement.Context, effectiveOp)
+		if err != nil {
+			tx.AddError(err)
+			return
+		}
+
+		tx.Statement.ConnPool = pool
+	}
+}
+
+func (dr *DBResolver) resolverFor(table string) *resolver {
+	if v, ok := dr.resolvers.Load(table); ok {
+		return v.(*resolver)
+	}
+	return dr.global
+}
+
+func tableNameFor(db *gorm.DB, v interface{}) string {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(v); err == nil {
+		return stmt.Table
+	}
+	if name, ok := v.(string); ok {
+		return name
+	}
+	return ""
+}
+
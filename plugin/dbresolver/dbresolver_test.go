@@ -0,0 +1,31 @@
+package dbresolver
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID uint
+}
+
+// TestForResolvesTableNamesLazilyAtInitialize guards against a regression
+// where For/Register resolved table names against dr.DB before Initialize
+// had set it, dereferencing a nil *gorm.DB during schema.Parse.
+func TestForResolvesTableNamesLazilyAtInitialize(t *testing.T) {
+	dr := Register(Config{}).For(&widget{})
+
+	db, err := gorm.Open(nil, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error opening db: %v", err)
+	}
+
+	if err := dr.Initialize(db); err != nil {
+		t.Fatalf("unexpected error initializing: %v", err)
+	}
+
+	if dr.resolverFor("widgets") != dr.global {
+		t.Fatalf("expected the widgets table to resolve to the registered resolver")
+	}
+}
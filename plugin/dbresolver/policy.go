@@ -0,0 +1,21 @@
+package dbresolver
+
+import (
+	"math/rand"
+
+	"gorm.io/gorm"
+)
+
+// Policy picks one gorm.ConnPool out of a resolved pool list, e.g. to load
+// balance across replicas. Implement it for weighted or latency-aware
+// selection; RandomPolicy is the default.
+type Policy interface {
+	Resolve([]gorm.ConnPool) gorm.ConnPool
+}
+
+// RandomPolicy picks a pool uniformly at random.
+type RandomPolicy struct{}
+
+func (RandomPolicy) Resolve(pools []gorm.ConnPool) gorm.ConnPool {
+	return pools[rand.Intn(len(pools))]
+}
@@ -0,0 +1,39 @@
+package dbresolver
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakePool is a no-op gorm.ConnPool, good enough to compare identity against
+// in Policy tests that never actually issue a query.
+type fakePool struct{ name string }
+
+func (*fakePool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, nil
+}
+func (*fakePool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, nil
+}
+func (*fakePool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, nil
+}
+func (*fakePool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func TestRandomPolicyResolvesToAConfiguredPool(t *testing.T) {
+	a, b := &fakePool{name: "a"}, &fakePool{name: "b"}
+	pools := []gorm.ConnPool{a, b}
+
+	policy := RandomPolicy{}
+	for i := 0; i < 20; i++ {
+		picked := policy.Resolve(pools)
+		if picked != gorm.ConnPool(a) && picked != gorm.ConnPool(b) {
+			t.Fatalf("expected Resolve to return one of the configured pools, got %v", picked)
+		}
+	}
+}
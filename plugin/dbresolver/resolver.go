@@ -0,0 +1,82 @@
+package dbresolver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// resolver owns one Config's compiled sources/replicas and is shared by every
+// model/table that config was registered for, so it may be init'd more than
+// once (e.g. once as the default resolver and again per table it's `For`'d
+// to) — initOnce makes that safe.
+type resolver struct {
+	dbresolver *DBResolver
+	config     Config
+	policy     Policy
+
+	initOnce sync.Once
+	initErr  error
+	sources  []gorm.ConnPool
+	replicas []gorm.ConnPool
+}
+
+func newResolver(dr *DBResolver, config Config) *resolver {
+	policy := config.Policy
+	if policy == nil {
+		policy = RandomPolicy{}
+	}
+
+	return &resolver{dbresolver: dr, config: config, policy: policy}
+}
+
+// init opens a gorm.ConnPool for every configured source/replica dialector.
+// It is idempotent: only the first call actually opens connections.
+func (r *resolver) init() error {
+	r.initOnce.Do(func() {
+		for _, dialector := range r.config.Sources {
+			pool, err := openConnPool(dialector)
+			if err != nil {
+				r.initErr = fmt.Errorf("dbresolver: failed to open source: %w", err)
+				return
+			}
+			r.sources = append(r.sources, pool)
+		}
+
+		for _, dialector := range r.config.Replicas {
+			pool, err := openConnPool(dialector)
+			if err != nil {
+				r.initErr = fmt.Errorf("dbresolver: failed to open replica: %w", err)
+				return
+			}
+			r.replicas = append(r.replicas, pool)
+		}
+	})
+
+	return r.initErr
+}
+
+// resolve returns the ConnPool to use for op ("read" or "write").
+func (r *resolver) resolve(ctx context.Context, op string) (gorm.ConnPool, error) {
+	pools := r.sources
+	if op == "read" && len(r.replicas) > 0 {
+		pools = r.replicas
+	}
+
+	if len(pools) == 0 {
+		return nil, fmt.Errorf("dbresolver: no pool configured for op %q", op)
+	}
+
+	return r.policy.Resolve(pools), nil
+}
+
+// openConnPool is a var so tests can stub out the real gorm.Open round trip.
+var openConnPool = func(dialector gorm.Dialector) (gorm.ConnPool, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	return db.ConnPool, nil
+}
@@ -0,0 +1,30 @@
+package dbresolver
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestResolverInitOnlyOpensPoolsOnce(t *testing.T) {
+	opens := 0
+	original := openConnPool
+	openConnPool = func(dialector gorm.Dialector) (gorm.ConnPool, error) {
+		opens++
+		return &fakePool{}, nil
+	}
+	t.Cleanup(func() { openConnPool = original })
+
+	r := newResolver(nil, Config{Sources: []gorm.Dialector{nil}, Replicas: []gorm.Dialector{nil}})
+
+	if err := r.init(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.init(); err != nil {
+		t.Fatalf("unexpected error on second init: %v", err)
+	}
+
+	if opens != 2 {
+		t.Fatalf("expected exactly 2 opens (1 source + 1 replica) across both init() calls, got %d", opens)
+	}
+}
@@ -0,0 +1,101 @@
+// Package metrics emits query counters, duration histograms and rows-affected
+// counters for GORM operations without pulling in a tracer dependency; pair
+// it with tracing.New if spans are also wanted.
+package metrics
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"gorm.io/gorm"
+)
+
+const (
+	meterName            = "gorm.io/plugin/opentelemetry/metrics"
+	metricQueriesTotal   = "gorm_queries_total"
+	metricQueryDuration  = "gorm_query_duration_seconds"
+	metricRowsAffected   = "gorm_rows_affected"
+	startTimeSettingsKey = "otel:metrics:start"
+)
+
+// Plugin implements gorm.Plugin, recording metrics on every SQL operation.
+type Plugin struct {
+	meter    metric.Meter
+	queries  metric.Int64Counter
+	duration metric.Float64Histogram
+	rows     metric.Int64Counter
+}
+
+// New builds a metrics plugin backed by provider. Register it with:
+//
+//	plugin, err := metrics.New(otel.GetMeterProvider())
+//	db.Use(plugin)
+func New(provider metric.MeterProvider) (*Plugin, error) {
+	meter := provider.Meter(meterName)
+
+	queries, err := meter.Int64Counter(metricQueriesTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := meter.Float64Histogram(metricQueryDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := meter.Int64Counter(metricRowsAffected)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Plugin{meter: meter, queries: queries, duration: duration, rows: rows}, nil
+}
+
+func (*Plugin) Name() string { return "gorm:opentelemetry:metrics" }
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	for op, cb := range map[string]*gorm.Callback{
+		"create": db.Callback().Create(),
+		"query":  db.Callback().Query(),
+		"update": db.Callback().Update(),
+		"delete": db.Callback().Delete(),
+		"row":    db.Callback().Row(),
+		"raw":    db.Callback().Raw(),
+	} {
+		op := op
+		if err := cb.Before("*").Register("otel_metrics:before_"+op, p.before); err != nil {
+			return err
+		}
+		if err := cb.After("*").Register("otel_metrics:after_"+op, p.after(op)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Plugin) before(tx *gorm.DB) {
+	tx.InstanceSet(startTimeSettingsKey, time.Now())
+}
+
+func (p *Plugin) after(op string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+
+		started, ok := tx.InstanceGet(startTimeSettingsKey)
+		if !ok {
+			return
+		}
+
+		measurement := metric.WithAttributes(
+			attribute.String("db.operation", op),
+			attribute.String("db.sql.table", tx.Statement.Table),
+		)
+
+		p.queries.Add(ctx, 1, measurement)
+		p.duration.Record(ctx, time.Since(started.(time.Time)).Seconds(), measurement)
+		p.rows.Add(ctx, tx.Statement.RowsAffected, measurement)
+	}
+}
@@ -0,0 +1,135 @@
+// Package tracing adds OpenTelemetry spans for every GORM SQL operation. It
+// hooks into the same callback points `initializeCallbacks` wires up
+// (Create/Query/Update/Delete/Row/Raw), pulling the parent span out of
+// `Statement.Context` so `db.WithContext(ctx)` propagates as expected.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"gorm.io/gorm"
+)
+
+// SpanNameFormatter builds the span name for an operation against table.
+type SpanNameFormatter func(op, table string) string
+
+// Option configures the plugin.
+type Option func(*Plugin)
+
+// WithTracerProvider overrides the global tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(p *Plugin) { p.tracer = tp.Tracer(instrumentationName) }
+}
+
+// WithSpanNameFormatter overrides the default "gorm.<op> <table>" span names.
+func WithSpanNameFormatter(f SpanNameFormatter) Option {
+	return func(p *Plugin) { p.formatSpanName = f }
+}
+
+// WithoutStatementAttribute omits the literal SQL (db.statement) from spans,
+// for deployments that don't want query text (and its args) in traces.
+func WithoutStatementAttribute() Option {
+	return func(p *Plugin) { p.includeStatement = false }
+}
+
+const instrumentationName = "gorm.io/plugin/opentelemetry/tracing"
+
+// Plugin implements gorm.Plugin.
+type Plugin struct {
+	tracer           trace.Tracer
+	formatSpanName   SpanNameFormatter
+	includeStatement bool
+}
+
+// New builds a tracing plugin. Register it with `db.Use(tracing.New(opts...))`.
+func New(opts ...Option) *Plugin {
+	p := &Plugin{
+		tracer:           otel.Tracer(instrumentationName),
+		includeStatement: true,
+	}
+	p.formatSpanName = func(op, table string) string { return "gorm." + op + " " + table }
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+func (*Plugin) Name() string { return "gorm:opentelemetry:tracing" }
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	for op, cb := range map[string]*gorm.Callback{
+		"create": db.Callback().Create(),
+		"query":  db.Callback().Query(),
+		"update": db.Callback().Update(),
+		"delete": db.Callback().Delete(),
+		"row":    db.Callback().Row(),
+		"raw":    db.Callback().Raw(),
+	} {
+		op := op
+		if err := cb.Before("*").Register("otel:before_"+op, p.before(op)); err != nil {
+			return err
+		}
+		if err := cb.After("*").Register("otel:after_"+op, p.after(op)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type spanKey struct{}
+
+func (p *Plugin) before(op string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		table := tx.Statement.Table
+		ctx, span := p.tracer.Start(ctx, p.formatSpanName(op, table),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", dbSystem(tx)),
+				attribute.String("db.operation", op),
+				attribute.String("db.sql.table", table),
+			),
+		)
+
+		tx.Statement.Context = context.WithValue(ctx, spanKey{}, span)
+	}
+}
+
+func (p *Plugin) after(op string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		span, ok := tx.Statement.Context.Value(spanKey{}).(trace.Span)
+		if !ok {
+			return
+		}
+		defer span.End()
+
+		if p.includeStatement {
+			span.SetAttributes(attribute.String("db.statement", tx.Statement.SQL.String()))
+		}
+		span.SetAttributes(attribute.Int64("db.rows_affected", tx.Statement.RowsAffected))
+
+		if tx.Error != nil {
+			span.RecordError(tx.Error)
+			span.SetStatus(codes.Error, tx.Error.Error())
+		}
+	}
+}
+
+func dbSystem(tx *gorm.DB) string {
+	if tx.Dialector != nil {
+		return tx.Dialector.Name()
+	}
+	return "other_sql"
+}
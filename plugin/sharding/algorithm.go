@@ -0,0 +1,27 @@
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+)
+
+// Hash returns an Algorithm that maps a key's FNV-1a hash modulo n to a
+// zero-padded shard suffix, e.g. "03" for 64 shards.
+func Hash(n uint) Algorithm {
+	width := len(strconv.Itoa(int(n) - 1))
+
+	return func(value interface{}) (string, error) {
+		if n == 0 {
+			return "", fmt.Errorf("sharding: NumberOfShards must be > 0")
+		}
+
+		h := fnv.New64a()
+		if _, err := fmt.Fprint(h, value); err != nil {
+			return "", err
+		}
+
+		idx := h.Sum64() % uint64(n)
+		return fmt.Sprintf("%0*d", width, idx), nil
+	}
+}
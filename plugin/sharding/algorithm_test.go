@@ -0,0 +1,32 @@
+package sharding
+
+import "testing"
+
+func TestHashIsDeterministicAndInRange(t *testing.T) {
+	algo := Hash(64)
+
+	suffix, err := algo(int64(42))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	again, err := algo(int64(42))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if suffix != again {
+		t.Fatalf("expected Hash to be deterministic for the same value, got %q then %q", suffix, again)
+	}
+	if len(suffix) != 2 {
+		t.Fatalf("expected a zero-padded 2-digit suffix for 64 shards, got %q", suffix)
+	}
+}
+
+func TestHashRejectsZeroShards(t *testing.T) {
+	algo := Hash(0)
+
+	if _, err := algo("anything"); err == nil {
+		t.Fatalf("expected an error for NumberOfShards=0")
+	}
+}
@@ -0,0 +1,303 @@
+// Package sharding rewrites the target table name of a query based on a
+// sharding key extracted from the statement, so a single logical model can
+// be horizontally partitioned across many physical tables.
+package sharding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Algorithm maps a sharding key value to the table suffix that should hold
+// it, e.g. `hash(value) % NumberOfShards`.
+type Algorithm func(value interface{}) (suffix string, err error)
+
+// Config configures sharding for one model.
+type Config struct {
+	// ShardingKey is the column whose value selects the shard, e.g. "user_id".
+	ShardingKey string
+	// ShardingKeys supports composite sharding keys; when set, ShardingKey is ignored.
+	ShardingKeys []string
+	// NumberOfShards is the number of physical tables the model is split across.
+	NumberOfShards uint
+	// ShardingAlgorithm picks the suffix for a (possibly composite) key value; defaults to Hash.
+	ShardingAlgorithm Algorithm
+	// PrimaryKeyGenerator produces snowflake-like, globally unique primary keys per shard.
+	PrimaryKeyGenerator func(shardSuffix string) (interface{}, error)
+	// AllowFullScan permits queries that can't be localized to one shard. Such
+	// queries run against the unsuffixed table name; they are not spread
+	// across the shard tables.
+	AllowFullScan bool
+}
+
+// tableBinding scopes a Config to a set of not-yet-resolved models/table
+// names. Resolving the table name requires a real `*gorm.DB` (schema.Parse
+// reads its NamingStrategy and cacheStore), which isn't available until
+// Initialize runs, so For/Register stash the raw tables here instead of
+// resolving them eagerly.
+type tableBinding struct {
+	config Config
+	tables []interface{}
+}
+
+// Sharding implements gorm.Plugin. Register one per model with `For`.
+type Sharding struct {
+	configs  map[string]Config // table name -> Config, populated at Initialize
+	bindings []tableBinding
+}
+
+// Register returns a Sharding plugin configured for the given models/tables.
+func Register(config Config, tables ...interface{}) *Sharding {
+	s := &Sharding{configs: map[string]Config{}}
+	return s.For(config, tables...)
+}
+
+// For adds config, scoped to the given models/tables.
+func (s *Sharding) For(config Config, tables ...interface{}) *Sharding {
+	if config.ShardingAlgorithm == nil {
+		config.ShardingAlgorithm = Hash(config.NumberOfShards)
+	}
+
+	s.bindings = append(s.bindings, tableBinding{config: config, tables: tables})
+
+	return s
+}
+
+func (Sharding) Name() string { return "gorm:sharding" }
+
+func (s *Sharding) Initialize(db *gorm.DB) error {
+	for _, binding := range s.bindings {
+		for _, t := range binding.tables {
+			s.configs[tableName(db, t)] = binding.config
+		}
+	}
+
+	db.Callback().Create().Before("gorm:create").Register("sharding:before_create", s.resolveCreate)
+	db.Callback().Query().Before("gorm:query").Register("sharding:before_query", s.resolve)
+	db.Callback().Update().Before("gorm:update").Register("sharding:before_update", s.resolve)
+	db.Callback().Delete().Before("gorm:delete").Register("sharding:before_delete", s.resolve)
+	return nil
+}
+
+// resolve rewrites tx.Statement.Table (and its FROM clause) to the sharded
+// table name. It is shared by query/update/delete, none of which should
+// generate a primary key.
+func (s *Sharding) resolve(tx *gorm.DB) {
+	s.rewriteTable(tx)
+}
+
+// resolveCreate rewrites the table like resolve, and additionally generates
+// a primary key per row when a PrimaryKeyGenerator is configured. Primary
+// key generation only makes sense for creates: on update it would overwrite
+// the key being matched on, and on query it would mutate the destination.
+func (s *Sharding) resolveCreate(tx *gorm.DB) {
+	config, suffix, ok := s.rewriteTable(tx)
+	if !ok || config.PrimaryKeyGenerator == nil || tx.Statement.Schema == nil {
+		return
+	}
+	s.assignPrimaryKeys(tx, config, suffix)
+}
+
+// rewriteTable resolves the shard for tx and points tx.Statement.Table (and
+// its FROM clause) at it, returning the matched Config and shard suffix.
+func (s *Sharding) rewriteTable(tx *gorm.DB) (Config, string, bool) {
+	if tx.Error != nil {
+		return Config{}, "", false
+	}
+
+	table := tx.Statement.Table
+	config, ok := s.configs[table]
+	if !ok {
+		return Config{}, "", false
+	}
+
+	keys := config.ShardingKeys
+	if len(keys) == 0 {
+		keys = []string{config.ShardingKey}
+	}
+
+	values, ok := s.extractShardingValues(tx, keys)
+	if !ok {
+		if config.AllowFullScan {
+			return Config{}, "", false
+		}
+		tx.AddError(fmt.Errorf("sharding: query on %q cannot be localized to one shard; set AllowFullScan to allow a full scan", table))
+		return Config{}, "", false
+	}
+
+	suffix, err := config.ShardingAlgorithm(shardKeyValue(values))
+	if err != nil {
+		tx.AddError(fmt.Errorf("sharding: %w", err))
+		return Config{}, "", false
+	}
+
+	shardedTable := table + "_" + suffix
+	tx.Statement.Table = shardedTable
+	tx.Statement.Clauses["FROM"] = clause.Clause{Name: "FROM", Expression: clause.From{Tables: []clause.Table{{Name: shardedTable}}}}
+
+	return config, suffix, true
+}
+
+// assignPrimaryKeys generates and sets one primary key per row being
+// created, so a batch insert doesn't collide every row onto the same key.
+func (s *Sharding) assignPrimaryKeys(tx *gorm.DB, config Config, suffix string) {
+	field := tx.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return
+	}
+
+	rv := tx.Statement.ReflectValue
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			pk, err := config.PrimaryKeyGenerator(suffix)
+			if err != nil {
+				tx.AddError(fmt.Errorf("sharding: failed to generate primary key: %w", err))
+				return
+			}
+			if err := field.Set(tx.Statement.Context, rv.Index(i), pk); err != nil {
+				tx.AddError(fmt.Errorf("sharding: failed to set primary key: %w", err))
+				return
+			}
+		}
+		return
+	}
+
+	pk, err := config.PrimaryKeyGenerator(suffix)
+	if err != nil {
+		tx.AddError(fmt.Errorf("sharding: failed to generate primary key: %w", err))
+		return
+	}
+	if err := field.Set(tx.Statement.Context, rv, pk); err != nil {
+		tx.AddError(fmt.Errorf("sharding: failed to set primary key: %w", err))
+	}
+}
+
+// extractShardingValues looks up each sharding key's value, first in WHERE
+// clause equality conditions, then in the struct/map being created.
+func (s *Sharding) extractShardingValues(tx *gorm.DB, keys []string) ([]interface{}, bool) {
+	values := make([]interface{}, 0, len(keys))
+
+	for _, key := range keys {
+		if v, ok := valueFromWhere(tx, key); ok {
+			values = append(values, v)
+			continue
+		}
+		if v, ok := valueFromDest(tx, key); ok {
+			values = append(values, v)
+			continue
+		}
+		return nil, false
+	}
+
+	return values, true
+}
+
+func valueFromWhere(tx *gorm.DB, key string) (interface{}, bool) {
+	where, ok := tx.Statement.Clauses["WHERE"].Expression.(clause.Where)
+	if !ok {
+		return nil, false
+	}
+
+	for _, expr := range where.Exprs {
+		switch e := expr.(type) {
+		case clause.Eq:
+			if col, ok := e.Column.(clause.Column); ok && col.Name == key {
+				return e.Value, true
+			}
+			if name, ok := e.Column.(string); ok && name == key {
+				return e.Value, true
+			}
+		case clause.IN:
+			// Only a single-value IN can be localized to one shard.
+			if len(e.Values) != 1 {
+				continue
+			}
+			if col, ok := e.Column.(clause.Column); ok && col.Name == key {
+				return e.Values[0], true
+			}
+			if name, ok := e.Column.(string); ok && name == key {
+				return e.Values[0], true
+			}
+		case clause.Expr:
+			// Covers the common `Where("key = ?", v)` form, which gorm
+			// represents as a raw SQL fragment rather than a clause.Eq.
+			if v, ok := valueFromSimpleEqExpr(e, key); ok {
+				return v, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// valueFromSimpleEqExpr recognizes the narrow `"<key> = ?"` raw-SQL shape
+// produced by `Where("<key> = ?", v)` and similar single-placeholder
+// equality conditions, which gorm does not decompose into a clause.Eq.
+func valueFromSimpleEqExpr(e clause.Expr, key string) (interface{}, bool) {
+	if len(e.Vars) != 1 {
+		return nil, false
+	}
+
+	sql := strings.TrimSpace(e.SQL)
+	sql = strings.Trim(sql, "()")
+	sql = strings.TrimSpace(sql)
+
+	parts := strings.SplitN(sql, "=", 2)
+	if len(parts) != 2 {
+		return nil, false
+	}
+
+	col := strings.Trim(strings.TrimSpace(parts[0]), "`\"")
+	if idx := strings.LastIndex(col, "."); idx != -1 {
+		col = col[idx+1:]
+	}
+	if col != key || strings.TrimSpace(parts[1]) != "?" {
+		return nil, false
+	}
+
+	return e.Vars[0], true
+}
+
+func valueFromDest(tx *gorm.DB, key string) (interface{}, bool) {
+	if tx.Statement.Schema == nil {
+		return nil, false
+	}
+
+	field := tx.Statement.Schema.LookUpField(key)
+	if field == nil {
+		return nil, false
+	}
+
+	value, isZero := field.ValueOf(tx.Statement.Context, tx.Statement.ReflectValue)
+	if isZero {
+		return nil, false
+	}
+
+	return value, true
+}
+
+func shardKeyValue(values []interface{}) interface{} {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
+
+// tableName resolves v (a string table name, or a model) to its table name,
+// parsing models against db's real schema cache and naming strategy.
+func tableName(db *gorm.DB, v interface{}) string {
+	if name, ok := v.(string); ok {
+		return name
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(v); err == nil {
+		return stmt.Table
+	}
+
+	return ""
+}
@@ -0,0 +1,67 @@
+package sharding
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type order struct {
+	ID uint
+}
+
+// TestForResolvesTableNamesLazilyAtInitialize guards against a regression
+// where For/Register resolved table names against a hand-built *gorm.DB
+// with no cacheStore before Initialize had run, panicking inside
+// schema.Parse.
+func TestForResolvesTableNamesLazilyAtInitialize(t *testing.T) {
+	s := Register(Config{NumberOfShards: 4}, &order{})
+
+	db, err := gorm.Open(nil, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error opening db: %v", err)
+	}
+
+	if err := s.Initialize(db); err != nil {
+		t.Fatalf("unexpected error initializing: %v", err)
+	}
+
+	if _, ok := s.configs["orders"]; !ok {
+		t.Fatalf("expected the orders table to have a resolved config, got %v", s.configs)
+	}
+}
+
+func TestValueFromSimpleEqExprMatchesPlainEquality(t *testing.T) {
+	v, ok := valueFromSimpleEqExpr(clause.Expr{SQL: "user_id = ?", Vars: []interface{}{int64(7)}}, "user_id")
+	if !ok {
+		t.Fatalf("expected to extract the sharding key from a simple equality expr")
+	}
+	if v != int64(7) {
+		t.Fatalf("expected extracted value 7, got %v", v)
+	}
+}
+
+func TestValueFromSimpleEqExprIgnoresOtherColumns(t *testing.T) {
+	if _, ok := valueFromSimpleEqExpr(clause.Expr{SQL: "status = ?", Vars: []interface{}{"active"}}, "user_id"); ok {
+		t.Fatalf("expected no match when the expr references a different column")
+	}
+}
+
+func TestValueFromSimpleEqExprIgnoresMultiArgExprs(t *testing.T) {
+	if _, ok := valueFromSimpleEqExpr(clause.Expr{SQL: "user_id = ? AND status = ?", Vars: []interface{}{int64(7), "active"}}, "user_id"); ok {
+		t.Fatalf("expected no match for a multi-placeholder expression")
+	}
+}
+
+func TestShardKeyValueSingleVsComposite(t *testing.T) {
+	if v := shardKeyValue([]interface{}{"a"}); v != "a" {
+		t.Fatalf("expected the single value unwrapped, got %v", v)
+	}
+
+	composite := shardKeyValue([]interface{}{"a", "b"})
+	values, ok := composite.([]interface{})
+	if !ok || len(values) != 2 {
+		t.Fatalf("expected composite keys to stay a slice, got %v", composite)
+	}
+}
@@ -0,0 +1,346 @@
+package gorm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// Stmt wraps a cached *sql.Stmt with the bookkeeping PreparedStmtDB needs to
+// evict it safely: a reference count so an in-flight query can keep it alive
+// past eviction, and the last-used time for TTL expiry.
+type Stmt struct {
+	*sql.Stmt
+	Transaction bool
+	prepared    chan struct{}
+	prepareErr  error
+
+	refs     int
+	lastUsed time.Time
+	element  *list.Element
+}
+
+// PrepareStmtDBStats is returned by PreparedStmtDB.Stats(), mirroring the
+// shape of sql.DB.Stats() for the statement cache.
+type PrepareStmtDBStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Size      int
+}
+
+// PreparedStmtDBConfig configures the bounds of a PreparedStmtDB's cache.
+type PreparedStmtDBConfig struct {
+	// MaxOpenStmts bounds the number of cached statements; 0 means unbounded.
+	MaxOpenStmts int
+	// StmtTTL evicts a statement that has been idle longer than this; 0 disables TTL eviction.
+	StmtTTL time.Duration
+}
+
+// PreparedStmtDB wraps a ConnPool with a bounded, TTL-aware cache of prepared
+// statements, keyed by SQL text. It implements ConnPool so it can be used
+// anywhere a *sql.DB / *sql.Tx can.
+type PreparedStmtDB struct {
+	Stmts map[string]*Stmt
+	Mux   sync.Mutex
+	ConnPool
+
+	config   PreparedStmtDBConfig
+	lru      *list.List // of stmtKey, most-recently-used at the back
+	closed   bool
+	closeCh  chan struct{}
+	inflight sync.WaitGroup
+
+	hits, misses, evictions int64
+}
+
+type stmtKey struct {
+	query string
+}
+
+// NewPreparedStmtDB wraps connPool with a bounded LRU statement cache.
+func NewPreparedStmtDB(connPool ConnPool, config PreparedStmtDBConfig) *PreparedStmtDB {
+	return &PreparedStmtDB{
+		ConnPool: connPool,
+		Stmts:    map[string]*Stmt{},
+		config:   config,
+		lru:      list.New(),
+		closeCh:  make(chan struct{}),
+	}
+}
+
+func (db *PreparedStmtDB) Stats() PrepareStmtDBStats {
+	db.Mux.Lock()
+	defer db.Mux.Unlock()
+
+	return PrepareStmtDBStats{
+		Hits:      db.hits,
+		Misses:    db.misses,
+		Evictions: db.evictions,
+		Size:      len(db.Stmts),
+	}
+}
+
+// Close drains any in-flight prepares and closes every cached statement. It
+// respects ctx so callers can bound how long they wait for in-flight work.
+func (db *PreparedStmtDB) Close(ctx context.Context) error {
+	db.Mux.Lock()
+	if db.closed {
+		db.Mux.Unlock()
+		return nil
+	}
+	db.closed = true
+	close(db.closeCh)
+	db.Mux.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		db.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	db.Mux.Lock()
+	defer db.Mux.Unlock()
+
+	for query, stmt := range db.Stmts {
+		db.evictLocked(query, stmt)
+	}
+	return nil
+}
+
+// evictLocked closes stmt and removes its bookkeeping. Callers must hold db.Mux.
+func (db *PreparedStmtDB) evictLocked(query string, stmt *Stmt) {
+	delete(db.Stmts, query)
+	if stmt.element != nil {
+		db.lru.Remove(stmt.element)
+	}
+	db.evictions++
+
+	if stmt.refs > 0 {
+		// still in use by an in-flight query; the last releaser closes it.
+		return
+	}
+	if stmt.Stmt != nil {
+		go stmt.Stmt.Close()
+	}
+}
+
+func (db *PreparedStmtDB) release(query string, stmt *Stmt) {
+	db.Mux.Lock()
+	defer db.Mux.Unlock()
+
+	stmt.refs--
+	if _, ok := db.Stmts[query]; !ok && stmt.refs <= 0 && stmt.Stmt != nil {
+		// it was evicted while we held it
+		go stmt.Stmt.Close()
+	}
+}
+
+// evictExpiredLocked removes statements idle longer than StmtTTL. Callers must hold db.Mux.
+func (db *PreparedStmtDB) evictExpiredLocked() {
+	if db.config.StmtTTL <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-db.config.StmtTTL)
+	for query, stmt := range db.Stmts {
+		if stmt.refs == 0 && stmt.lastUsed.Before(cutoff) {
+			db.evictLocked(query, stmt)
+		}
+	}
+}
+
+// evictLRULocked evicts the least-recently-used statement to make room under
+// MaxOpenStmts. Callers must hold db.Mux.
+func (db *PreparedStmtDB) evictLRULocked() {
+	if db.config.MaxOpenStmts <= 0 || len(db.Stmts) < db.config.MaxOpenStmts {
+		return
+	}
+
+	for e := db.lru.Front(); e != nil; e = e.Next() {
+		key := e.Value.(stmtKey)
+		if stmt, ok := db.Stmts[key.query]; ok && stmt.refs == 0 {
+			db.evictLocked(key.query, stmt)
+			return
+		}
+	}
+}
+
+// prepare returns the cached *Stmt for query, preparing and caching a new one
+// if needed. The returned *Stmt is the actual cache entry (not a copy), with
+// refs already incremented; callers must release every *Stmt returned with a
+// nil error. On error there is nothing for the caller to release.
+func (db *PreparedStmtDB) prepare(ctx context.Context, conn ConnPool, isTransaction bool, query string) (*Stmt, error) {
+	db.Mux.Lock()
+	if db.closed {
+		db.Mux.Unlock()
+		return nil, ErrInvalidDB
+	}
+
+	if stmt, ok := db.Stmts[query]; ok {
+		db.hits++
+		stmt.refs++
+		stmt.lastUsed = time.Now()
+		if stmt.element != nil {
+			db.lru.MoveToBack(stmt.element)
+		}
+		db.Mux.Unlock()
+
+		<-stmt.prepared
+		if stmt.prepareErr != nil {
+			db.release(query, stmt)
+		}
+		return stmt, stmt.prepareErr
+	}
+
+	db.misses++
+	db.evictExpiredLocked()
+	db.evictLRULocked()
+
+	cs := &Stmt{prepared: make(chan struct{}), refs: 1, lastUsed: time.Now()}
+	cs.element = db.lru.PushBack(stmtKey{query: query})
+	db.Stmts[query] = cs
+	db.Mux.Unlock()
+
+	db.inflight.Add(1)
+	defer db.inflight.Done()
+
+	stmt, err := db.prepareWithContext(ctx, conn, query)
+
+	db.Mux.Lock()
+	cs.Stmt = stmt
+	cs.Transaction = isTransaction
+	cs.prepareErr = err
+	close(cs.prepared)
+	if err != nil {
+		db.evictLocked(query, cs)
+	}
+	db.Mux.Unlock()
+
+	return cs, err
+}
+
+func (db *PreparedStmtDB) prepareWithContext(ctx context.Context, conn ConnPool, query string) (*sql.Stmt, error) {
+	type result struct {
+		stmt *sql.Stmt
+		err  error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		stmt, err := conn.PrepareContext(ctx, query)
+		done <- result{stmt, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.stmt, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (db *PreparedStmtDB) BeginTx(ctx context.Context, opt *sql.TxOptions) (ConnPool, error) {
+	if beginner, ok := db.ConnPool.(TxBeginner); ok {
+		tx, err := beginner.BeginTx(ctx, opt)
+		return &PreparedStmtTX{PreparedStmtDB: db, Tx: tx}, err
+	}
+	return nil, ErrInvalidTransaction
+}
+
+func (db *PreparedStmtDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := db.prepare(ctx, db.ConnPool, false, query)
+	if err != nil {
+		return nil, err
+	}
+	defer db.release(query, stmt)
+	result, err := stmt.ExecContext(ctx, args...)
+	return result, err
+}
+
+// QueryContext returns *sql.Rows backed by the cached *sql.Stmt. ConnPool
+// fixes the return type at the concrete *sql.Rows, so unlike ExecContext's
+// sql.Result there's no room to wrap the result and defer release until the
+// caller closes it; release like the other methods do instead, right after
+// the call that needs stmt, and lean on database/sql's own guarantee that
+// closing a *sql.Stmt defers the underlying driver statement's close until
+// every *sql.Rows obtained from it has released its connection.
+func (db *PreparedStmtDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := db.prepare(ctx, db.ConnPool, false, query)
+	if err != nil {
+		return nil, err
+	}
+	defer db.release(query, stmt)
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (db *PreparedStmtDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := db.prepare(ctx, db.ConnPool, false, query)
+	if err != nil {
+		return &sql.Row{}
+	}
+	defer db.release(query, stmt)
+	return stmt.QueryRowContext(ctx, args...)
+}
+
+// PreparedStmtTX pins prepared statements to a single in-flight transaction,
+// delegating the cache lookups to the parent PreparedStmtDB while running the
+// actual queries against db.Tx.
+type PreparedStmtTX struct {
+	*PreparedStmtDB
+	Tx ConnPool
+}
+
+func (tx *PreparedStmtTX) Commit() error {
+	if committer, ok := tx.Tx.(TxCommitter); ok && tx.Tx != nil {
+		return committer.Commit()
+	}
+	return ErrInvalidTransaction
+}
+
+func (tx *PreparedStmtTX) Rollback() error {
+	if committer, ok := tx.Tx.(TxCommitter); ok && tx.Tx != nil {
+		return committer.Rollback()
+	}
+	return ErrInvalidTransaction
+}
+
+func (tx *PreparedStmtTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := tx.prepare(ctx, tx.Tx, true, query)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.release(query, stmt)
+	return tx.Tx.(interface {
+		Stmt(*sql.Stmt) *sql.Stmt
+	}).Stmt(stmt.Stmt).ExecContext(ctx, args...)
+}
+
+func (tx *PreparedStmtTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := tx.prepare(ctx, tx.Tx, true, query)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.release(query, stmt)
+	return tx.Tx.(interface {
+		Stmt(*sql.Stmt) *sql.Stmt
+	}).Stmt(stmt.Stmt).QueryContext(ctx, args...)
+}
+
+func (tx *PreparedStmtTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := tx.prepare(ctx, tx.Tx, true, query)
+	if err != nil {
+		return &sql.Row{}
+	}
+	defer tx.release(query, stmt)
+	return tx.Tx.(interface {
+		Stmt(*sql.Stmt) *sql.Stmt
+	}).Stmt(stmt.Stmt).QueryRowContext(ctx, args...)
+}
@@ -0,0 +1,140 @@
+package gorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDriver is a minimal database/sql driver good enough to hand out real
+// *sql.Stmt values to PreparedStmtDB without touching a real database.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (*fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{}, nil }
+func (*fakeConn) Close() error                              { return nil }
+func (*fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+type fakeStmt struct{}
+
+func (*fakeStmt) Close() error                                    { return nil }
+func (*fakeStmt) NumInput() int                                   { return -1 }
+func (*fakeStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.ResultNoRows, nil }
+func (*fakeStmt) Query(args []driver.Value) (driver.Rows, error)  { return &fakeRows{}, nil }
+
+type fakeRows struct{}
+
+func (*fakeRows) Columns() []string             { return nil }
+func (*fakeRows) Close() error                  { return nil }
+func (*fakeRows) Next(dest []driver.Value) error { return sql.ErrNoRows }
+
+var registerFakeDriverOnce sync.Once
+
+func newFakeConnPool(t *testing.T) *sql.DB {
+	t.Helper()
+	registerFakeDriverOnce.Do(func() { sql.Register("gorm-fake", fakeDriver{}) })
+	db, err := sql.Open("gorm-fake", "")
+	if err != nil {
+		t.Fatalf("failed to open fake db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestPreparedStmtDBCachesByQuery(t *testing.T) {
+	db := NewPreparedStmtDB(newFakeConnPool(t), PreparedStmtDBConfig{})
+
+	if _, err := db.ExecContext(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats := db.Stats(); stats.Misses != 1 || stats.Hits != 0 {
+		t.Fatalf("expected 1 miss and 0 hits after first prepare, got %+v", stats)
+	}
+
+	if _, err := db.ExecContext(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats := db.Stats(); stats.Misses != 1 || stats.Hits != 1 {
+		t.Fatalf("expected 1 miss and 1 hit after second prepare, got %+v", stats)
+	}
+}
+
+func TestPreparedStmtDBRefcountReturnsToZeroAfterRelease(t *testing.T) {
+	db := NewPreparedStmtDB(newFakeConnPool(t), PreparedStmtDBConfig{})
+
+	if _, err := db.ExecContext(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	db.Mux.Lock()
+	stmt := db.Stmts["SELECT 1"]
+	db.Mux.Unlock()
+
+	if stmt.refs != 0 {
+		t.Fatalf("expected refs to return to 0 after release, got %d", stmt.refs)
+	}
+}
+
+func TestPreparedStmtDBQueryContextReleasesAfterReturningRows(t *testing.T) {
+	db := NewPreparedStmtDB(newFakeConnPool(t), PreparedStmtDBConfig{})
+
+	rows, err := db.QueryContext(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rows.Close()
+
+	db.Mux.Lock()
+	stmt := db.Stmts["SELECT 1"]
+	db.Mux.Unlock()
+
+	if stmt.refs != 0 {
+		t.Fatalf("expected refs to return to 0 once QueryContext has returned, got %d", stmt.refs)
+	}
+}
+
+func TestPreparedStmtDBEvictsLeastRecentlyUsedOverMaxOpenStmts(t *testing.T) {
+	db := NewPreparedStmtDB(newFakeConnPool(t), PreparedStmtDBConfig{MaxOpenStmts: 2})
+
+	for _, q := range []string{"SELECT 1", "SELECT 2", "SELECT 3"} {
+		if _, err := db.ExecContext(context.Background(), q); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	stats := db.Stats()
+	if stats.Size != 2 {
+		t.Fatalf("expected cache size to stay at MaxOpenStmts=2, got %d", stats.Size)
+	}
+	if stats.Evictions != 1 {
+		t.Fatalf("expected exactly 1 eviction, got %d", stats.Evictions)
+	}
+	if _, ok := db.Stmts["SELECT 1"]; ok {
+		t.Fatalf("expected the least-recently-used statement to be evicted")
+	}
+}
+
+func TestPreparedStmtDBEvictsExpiredByTTL(t *testing.T) {
+	db := NewPreparedStmtDB(newFakeConnPool(t), PreparedStmtDBConfig{StmtTTL: time.Millisecond})
+
+	if _, err := db.ExecContext(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	// Triggers evictExpiredLocked as a side effect of preparing a new statement.
+	if _, err := db.ExecContext(context.Background(), "SELECT 2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := db.Stmts["SELECT 1"]; ok {
+		t.Fatalf("expected idle statement past its TTL to be evicted")
+	}
+}